@@ -0,0 +1,66 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"testing"
+)
+
+// recordingLog records the severity and event ID of the last call made to it.
+type recordingLog struct {
+	discardLog
+	severity string
+	eid      uint32
+}
+
+func (l *recordingLog) Error(eid uint32, msg string) error {
+	l.severity, l.eid = "error", eid
+	return nil
+}
+
+func (l *recordingLog) Warning(eid uint32, msg string) error {
+	l.severity, l.eid = "warning", eid
+	return nil
+}
+
+func (l *recordingLog) Info(eid uint32, msg string) error {
+	l.severity, l.eid = "info", eid
+	return nil
+}
+
+func TestEventlogWriter_Write(t *testing.T) {
+	tests := []struct {
+		name         string
+		line         string
+		wantSeverity string
+		wantEventID  uint32
+	}{
+		{"replicate error", "replicate: sync error: dial tcp: connection refused", "error", eventIDReplicate},
+		{"snapshot warning", "snapshot: checkpoint warning: database is locked", "warning", eventIDSnapshot},
+		{"retention info", "retention: removed 3 expired generations", "info", eventIDRetention},
+		{"http tag not url", "http: listen error: address already in use", "error", eventIDHTTP},
+		{"http url inside replicate line", "replicate: dial tcp to http://backup.example.com failed", "info", eventIDReplicate},
+		{"https url inside replicate line", "replicate: dial tcp to https://backup.example.com failed", "info", eventIDReplicate},
+		{"unrecognized subsystem", "starting up", "info", eventIDGeneral},
+		{"host name containing warn is not a warning", "snapshot: forwarding to warner.example.com", "info", eventIDSnapshot},
+		{"errors as a whole word", "retention: 3 errors during last run", "error", eventIDRetention},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := &recordingLog{}
+			w := &eventlogWriter{log: log}
+
+			if _, err := w.Write([]byte(tt.line)); err != nil {
+				t.Fatalf("Write returned error: %s", err)
+			}
+			if log.severity != tt.wantSeverity {
+				t.Errorf("severity = %q, want %q", log.severity, tt.wantSeverity)
+			}
+			if log.eid != tt.wantEventID {
+				t.Errorf("event ID = %d, want %d", log.eid, tt.wantEventID)
+			}
+		})
+	}
+}
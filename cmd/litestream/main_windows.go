@@ -8,8 +8,12 @@ import (
 	"io"
 	"log"
 	"os"
+	"strings"
+	"time"
+	"unicode"
 
 	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/debug"
 	"golang.org/x/sys/windows/svc/eventlog"
 )
 
@@ -21,7 +25,22 @@ func isWindowsService() (bool, error) {
 	return svc.IsWindowsService()
 }
 
+// runWindowsService runs Litestream as a Windows service, registering with
+// the Service Control Manager and logging to the Windows Event Log.
 func runWindowsService(ctx context.Context) error {
+	return runWindowsServiceMode(ctx, false)
+}
+
+// runWindowsServiceMode runs Litestream as a Windows service. If debugMode is
+// true, it runs interactively via svc/debug instead of registering with the
+// Windows Service Control Manager, logging to stderr instead of the Event
+// Log. This lets operators exercise service behavior without repeatedly
+// installing/uninstalling the service.
+func runWindowsServiceMode(ctx context.Context, debugMode bool) error {
+	if debugMode {
+		return runWindowsServiceDebug(ctx)
+	}
+
 	elog, err := eventlog.Open(serviceName)
 	if err != nil {
 		return err
@@ -29,7 +48,7 @@ func runWindowsService(ctx context.Context) error {
 	defer elog.Close()
 
 	// Set eventlog as log writer while running.
-	log.SetOutput((*eventlogWriter)(elog))
+	log.SetOutput(&eventlogWriter{log: elog})
 	defer log.SetOutput(os.Stderr)
 
 	if err := eventlog.InstallAsEventCreate(serviceName, eventlog.Error|eventlog.Warning|eventlog.Info); err != nil {
@@ -38,7 +57,26 @@ func runWindowsService(ctx context.Context) error {
 
 	elog.Info(1, "Litestream service starting")
 
-	if err := svc.Run(serviceName, &windowsService{ctx: ctx, elog: elog}); err != nil {
+	if err := svc.Run(serviceName, newWindowsService(ctx, elog)); err != nil {
+		elog.Error(1, fmt.Sprintf("Litestream service failed: %s", err))
+		return errStop
+	}
+	elog.Info(1, "Litestream service exited")
+	return nil
+}
+
+// runWindowsServiceDebug runs the service handler under debug.Run so it can
+// be driven from an interactive console session. Ctrl+Break/Ctrl+C are
+// translated into svc.Stop change requests by the debug package.
+func runWindowsServiceDebug(ctx context.Context) error {
+	elog := debug.New(serviceName)
+
+	log.SetOutput(&debugWriter{log: elog})
+	defer log.SetOutput(os.Stderr)
+
+	elog.Info(1, "Litestream service starting (debug mode)")
+
+	if err := debug.Run(serviceName, newWindowsService(ctx, elog)); err != nil {
 		elog.Error(1, fmt.Sprintf("Litestream service failed: %s", err))
 		return errStop
 	}
@@ -49,11 +87,45 @@ func runWindowsService(ctx context.Context) error {
 // windowsService is an interface adapter for svc.Handler.
 type windowsService struct {
 	ctx  context.Context
-	elog *eventlog.Log
+	elog debug.Log
+
+	// drainInterval is how often a StopPending checkpoint is reported to the
+	// Service Control Manager while waiting for replication to drain, so the
+	// SCM keeps extending the stop timeout instead of killing the process.
+	// It is a field rather than a package constant so tests can shrink it.
+	drainInterval time.Duration
 }
 
+// defaultDrainInterval is the production value of windowsService.drainInterval.
+const defaultDrainInterval = 2 * time.Second
+
+// newWindowsService returns a windowsService ready to be passed to svc.Run or
+// debug.Run.
+func newWindowsService(ctx context.Context, elog debug.Log) *windowsService {
+	return &windowsService{ctx: ctx, elog: elog, drainInterval: defaultDrainInterval}
+}
+
+// replicaDrainer is satisfied by ReplicateCommand. It exists so the drain
+// loop can be tested without constructing a full ReplicateCommand.
+type replicaDrainer interface {
+	Close() error
+}
+
+// Power-event and session-change-reason codes. These aren't exposed as
+// constants by the svc package, which only hands back the raw EventType
+// carried through RegisterServiceCtrlHandlerEx.
+const (
+	pbtAPMSuspend         = 4  // PBT_APMSUSPEND
+	pbtAPMResumeAutomatic = 18 // PBT_APMRESUMEAUTOMATIC
+
+	wtsRemoteConnect = 3 // WTS_REMOTE_CONNECT
+	wtsSessionLogoff = 6 // WTS_SESSION_LOGOFF
+	wtsSessionLock   = 7 // WTS_SESSION_LOCK
+)
+
 func (s *windowsService) Execute(args []string, changeReqCh <-chan svc.ChangeRequest, statusCh chan<- svc.Status) (ssec bool, errno uint32) {
-	const accepts = svc.AcceptStop | svc.AcceptShutdown | svc.AcceptPauseAndContinue
+	const accepts = svc.AcceptStop | svc.AcceptShutdown | svc.AcceptPreShutdown | svc.AcceptPauseAndContinue |
+		svc.AcceptSessionChange | svc.AcceptPowerEvent
 
 	statusCh <- svc.Status{State: svc.StartPending}
 
@@ -62,44 +134,195 @@ func (s *windowsService) Execute(args []string, changeReqCh <-chan svc.ChangeReq
 
 	statusCh <- svc.Status{State: svc.Running, Accepts: accepts}
 
+loop:
 	for {
 		select {
 		case changeReq := <-changeReqCh:
 			switch changeReq.Cmd {
 			case svc.Interrogate:
-				s.elog.Info(1, "Litestream service interrograted")
+				s.elog.Info(eventIDGeneral, "Litestream service interrograted")
 				statusCh <- changeReq.CurrentStatus
-			case svc.Stop:
-				s.elog.Info(1, "Litestream service stopped")
-				c.Close()
-				statusCh <- svc.Status{State: svc.StopPending}
-			case svc.Shutdown:
-				s.elog.Info(1, "Litestream service shutting down")
-				c.Close()
-				statusCh <- svc.Status{State: svc.StopPending}
+			case svc.Stop, svc.Shutdown, svc.PreShutdown:
+				s.elog.Info(eventIDReplicate, "Litestream service stopping, draining replicas")
+				s.drain(c, statusCh)
+				break loop
 			case svc.Pause:
-				s.elog.Info(1, "Litestream service paused")
-				c.Close()
+				// Pause() stops each DB's sync monitor loop and closes any
+				// long-lived replica connections, but leaves DB shadow WAL state and
+				// file handles intact so Continue can resume without a full re-open.
+				s.elog.Info(eventIDReplicate, "Litestream service paused")
+				if err := c.Pause(); err != nil {
+					s.elog.Error(eventIDReplicate, fmt.Sprintf("error pausing replication: %s", err))
+				}
 				statusCh <- svc.Status{State: svc.Paused, Accepts: accepts}
 			case svc.Continue:
-				s.elog.Info(1, "Litestream service continuing")
-				c.Close()
-				c = NewReplicateCommand()
-				c.Run(s.ctx)
+				s.elog.Info(eventIDReplicate, "Litestream service continuing")
+				if err := c.Resume(); err != nil {
+					s.elog.Error(eventIDReplicate, fmt.Sprintf("error resuming replication: %s", err))
+				}
 				statusCh <- svc.Status{State: svc.Running, Accepts: accepts}
+			case svc.SessionChange:
+				switch changeReq.EventType {
+				case wtsSessionLogoff, wtsSessionLock, wtsRemoteConnect:
+					s.elog.Info(eventIDSnapshot, "session change detected, flushing replicated databases")
+					if err := c.Checkpoint(s.ctx); err != nil {
+						s.elog.Warning(eventIDSnapshot, fmt.Sprintf("error flushing on session change: %s", err))
+					}
+				}
+				statusCh <- changeReq.CurrentStatus
+			case svc.PowerEvent:
+				switch changeReq.EventType {
+				case pbtAPMSuspend:
+					s.elog.Info(eventIDSnapshot, "system suspending, flushing replicated databases")
+					if err := c.Checkpoint(s.ctx); err != nil {
+						s.elog.Warning(eventIDSnapshot, fmt.Sprintf("error flushing before suspend: %s", err))
+					}
+				case pbtAPMResumeAutomatic:
+					s.elog.Info(eventIDReplicate, "system resumed, verifying replica connectivity")
+					if err := c.VerifyReplicas(s.ctx); err != nil {
+						s.elog.Warning(eventIDReplicate, fmt.Sprintf("error verifying replicas after resume: %s", err))
+					}
+				}
+				statusCh <- changeReq.CurrentStatus
 			default:
-				s.elog.Error(1, fmt.Sprintf("unexpected control request #%d", changeReq))
+				s.elog.Error(eventIDGeneral, fmt.Sprintf("unexpected control request #%d", changeReq))
 			}
 		}
 	}
+
+	statusCh <- svc.Status{State: svc.Stopped}
+	return false, 0
 }
 
+// drain closes c and blocks until its replicas finish uploading, reporting
+// periodic StopPending checkpoints so the SCM extends the stop timeout
+// rather than terminating the process mid-upload. This guarantees the last
+// WAL segment is replicated before Windows tears down the process during
+// shutdown or reboot.
+func (s *windowsService) drain(c replicaDrainer, statusCh chan<- svc.Status) {
+	waitHint := uint32(2 * s.drainInterval.Milliseconds())
+	var checkpoint uint32 = 1
+	statusCh <- svc.Status{State: svc.StopPending, CheckPoint: checkpoint, WaitHint: waitHint}
+
+	done := make(chan error, 1)
+	go func() { done <- c.Close() }()
+
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				s.elog.Warning(eventIDReplicate, fmt.Sprintf("error draining replicas: %s", err))
+			}
+			return
+		case <-time.After(s.drainInterval):
+			checkpoint++
+			statusCh <- svc.Status{State: svc.StopPending, CheckPoint: checkpoint, WaitHint: waitHint}
+		}
+	}
+}
+
+// Event IDs for the Windows Event Log, grouped by subsystem so administrators
+// can filter Event Viewer by ID and forward specific IDs to Windows Event
+// Forwarding / SIEMs.
+const (
+	eventIDGeneral   = 1
+	eventIDReplicate = 100
+	eventIDSnapshot  = 200
+	eventIDRetention = 300
+	eventIDHTTP      = 400
+)
+
 // Ensure implementation implements io.Writer interface.
 var _ io.Writer = (*eventlogWriter)(nil)
 
-// eventlogWriter is an adapter for using eventlog.Log as an io.Writer.
-type eventlogWriter eventlog.Log
+// eventlogWriter is an adapter for using eventlog.Log as an io.Writer. Unlike
+// a plain passthrough, it inspects each Litestream log line for its severity
+// and subsystem so genuine errors and warnings show up correctly in Event
+// Viewer instead of as Information events. Litestream doesn't log through a
+// fixed "ERROR:"/"WARN:" prefix convention; log.Printf call sites instead
+// embed the word in the message itself (e.g. "sync error: %s", "retention:
+// checkpoint warning: %s"), so severity is detected by looking for
+// "error"/"errors" or "warn"/"warning"/"warnings" as whole words,
+// case-insensitively, rather than a strict prefix or a bare substring (which
+// would also fire on host names or identifiers that merely contain those
+// letters, e.g. "warner.example.com").
+//
+// It takes a debug.Log rather than a concrete *eventlog.Log so it can wrap
+// either the real Event Log or, in tests, a stand-in that satisfies the same
+// interface. Debug mode logs through debugWriter instead.
+type eventlogWriter struct {
+	log debug.Log
+}
 
 func (w *eventlogWriter) Write(p []byte) (n int, err error) {
-	return 0, (*eventlog.Log)(w).Info(1, string(p))
+	msg := string(p)
+	eid := eventIDForSubsystem(msg)
+
+	switch lower := strings.ToLower(msg); {
+	case hasWord(lower, "error", "errors"):
+		err = w.log.Error(eid, msg)
+	case hasWord(lower, "warn", "warning", "warnings"):
+		err = w.log.Warning(eid, msg)
+	default:
+		err = w.log.Info(eid, msg)
+	}
+	return len(p), err
+}
+
+// hasWord reports whether lower (already lowercased) contains any of words
+// as a standalone alphabetic token, e.g. "errors" matches "errors" but not
+// "warner.example.com" matching "warn".
+func hasWord(lower string, words ...string) bool {
+	for _, field := range strings.FieldsFunc(lower, func(r rune) bool { return !unicode.IsLetter(r) }) {
+		for _, word := range words {
+			if field == word {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// eventIDForSubsystem maps a log line to a subsystem-specific event ID based
+// on the component name Litestream logs alongside each message (replicate,
+// snapshot, retention, http). Matching is case-insensitive and ignores
+// punctuation, since call sites format these names inconsistently (e.g.
+// "replicate:", "Replica", "snapshot "). It picks whichever subsystem name
+// appears earliest in the line, and ignores "http" when it's part of an
+// "http://" or "https://" URL rather than naming the http subsystem.
+func eventIDForSubsystem(msg string) uint32 {
+	lower := strings.ToLower(msg)
+
+	var best uint32 = eventIDGeneral
+	bestIdx := -1
+	consider := func(tag string, eid uint32) {
+		if idx := strings.Index(lower, tag); idx >= 0 && (bestIdx == -1 || idx < bestIdx) {
+			bestIdx, best = idx, eid
+		}
+	}
+
+	consider("replicat", eventIDReplicate) // matches "replicate" and "replica"
+	consider("snapshot", eventIDSnapshot)
+	consider("retention", eventIDRetention)
+	if idx := strings.Index(lower, "http"); idx >= 0 {
+		rest := lower[idx:]
+		if !strings.HasPrefix(rest, "http://") && !strings.HasPrefix(rest, "https://") {
+			consider("http", eventIDHTTP)
+		}
+	}
+
+	return best
+}
+
+// Ensure implementation implements io.Writer interface.
+var _ io.Writer = (*debugWriter)(nil)
+
+// debugWriter is an adapter for using a debug.Log as an io.Writer.
+type debugWriter struct {
+	log debug.Log
+}
+
+func (w *debugWriter) Write(p []byte) (n int, err error) {
+	err = w.log.Info(1, string(p))
+	return len(p), err
 }
@@ -0,0 +1,92 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+// fakeDrainer simulates a ReplicateCommand whose replicas take closeAfter to
+// finish uploading once Close is called.
+type fakeDrainer struct {
+	closeAfter time.Duration
+	closeErr   error
+}
+
+func (d *fakeDrainer) Close() error {
+	time.Sleep(d.closeAfter)
+	return d.closeErr
+}
+
+func TestWindowsService_drain(t *testing.T) {
+	s := &windowsService{elog: discardLog{}, drainInterval: 10 * time.Millisecond}
+	c := &fakeDrainer{closeAfter: 45 * time.Millisecond}
+
+	statusCh := make(chan svc.Status, 16)
+	done := make(chan struct{})
+	go func() {
+		s.drain(c, statusCh)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("drain did not return after replicas closed")
+	}
+	close(statusCh)
+
+	var checkpoints []uint32
+	for status := range statusCh {
+		if status.State != svc.StopPending {
+			t.Fatalf("unexpected state reported during drain: %v", status.State)
+		}
+		checkpoints = append(checkpoints, status.CheckPoint)
+	}
+
+	if len(checkpoints) < 2 {
+		t.Fatalf("expected multiple StopPending checkpoints, got %d", len(checkpoints))
+	}
+	for i, cp := range checkpoints {
+		if want := uint32(i + 1); cp != want {
+			t.Fatalf("checkpoint %d: got %d, want %d", i, cp, want)
+		}
+	}
+}
+
+func TestWindowsService_drain_reportsCloseError(t *testing.T) {
+	log := &capturingLog{}
+	s := &windowsService{elog: log, drainInterval: time.Hour}
+	c := &fakeDrainer{closeErr: errors.New("upload failed")}
+
+	statusCh := make(chan svc.Status, 16)
+	s.drain(c, statusCh)
+
+	if log.warnings == 0 {
+		t.Fatal("expected a Warning to be logged when Close returns an error")
+	}
+}
+
+// discardLog is a debug.Log that discards everything.
+type discardLog struct{}
+
+func (discardLog) Close() error                        { return nil }
+func (discardLog) Error(eid uint32, msg string) error   { return nil }
+func (discardLog) Warning(eid uint32, msg string) error { return nil }
+func (discardLog) Info(eid uint32, msg string) error    { return nil }
+
+// capturingLog counts Warning calls.
+type capturingLog struct {
+	discardLog
+	warnings int
+}
+
+func (l *capturingLog) Warning(eid uint32, msg string) error {
+	l.warnings++
+	return nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// Pause stops the sync tickers and closes any long-lived connections to
+// remote replicas for every DB under replication, but leaves shadow WAL
+// state and file handles intact so Resume can pick up where Pause left off
+// without a full re-open.
+func (c *ReplicateCommand) Pause() (err error) {
+	for _, db := range c.DBs {
+		if e := db.Pause(); e != nil && err == nil {
+			err = fmt.Errorf("pause db %q: %w", db.Path(), e)
+		}
+	}
+	return err
+}
+
+// Resume reconnects replicas and restarts replication for every DB paused by
+// a prior call to Pause.
+func (c *ReplicateCommand) Resume() (err error) {
+	for _, db := range c.DBs {
+		if e := db.Resume(); e != nil && err == nil {
+			err = fmt.Errorf("resume db %q: %w", db.Path(), e)
+		}
+	}
+	return err
+}
+
+// Checkpoint forces a WAL checkpoint and replica sync for every DB under
+// replication. It is used to flush outstanding writes ahead of a session
+// lock/logoff or system suspend.
+func (c *ReplicateCommand) Checkpoint(ctx context.Context) (err error) {
+	for _, db := range c.DBs {
+		if e := db.Checkpoint(ctx); e != nil && err == nil {
+			err = fmt.Errorf("checkpoint db %q: %w", db.Path(), e)
+		}
+	}
+	return err
+}
+
+// VerifyReplicas reconnects and confirms connectivity of every replica
+// across all DBs under replication. It is used after a system resume to
+// catch destinations that dropped their connection while suspended.
+func (c *ReplicateCommand) VerifyReplicas(ctx context.Context) (err error) {
+	for _, db := range c.DBs {
+		for _, r := range db.Replicas {
+			if e := r.Verify(ctx); e != nil && err == nil {
+				err = fmt.Errorf("verify replica %q on db %q: %w", r.Name, db.Path(), e)
+			}
+		}
+	}
+	return err
+}
@@ -0,0 +1,219 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/eventlog"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// ServiceCommand manages the lifecycle of the Windows service registration
+// for Litestream (install, uninstall, start, stop, status), and runs the
+// service handler interactively (debug).
+type ServiceCommand struct{}
+
+// NewServiceCommand returns a new instance of ServiceCommand.
+func NewServiceCommand() *ServiceCommand {
+	return &ServiceCommand{}
+}
+
+func (c *ServiceCommand) Run(ctx context.Context, args []string) (err error) {
+	if len(args) == 0 {
+		return fmt.Errorf("service subcommand required: install, uninstall, start, stop, status, debug")
+	}
+
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "install":
+		return c.runInstall(ctx, args)
+	case "uninstall":
+		return c.runUninstall(ctx, args)
+	case "start":
+		return c.runStart(ctx, args)
+	case "stop":
+		return c.runStop(ctx, args)
+	case "status":
+		return c.runStatus(ctx, args)
+	case "debug":
+		return c.runDebug(ctx, args)
+	default:
+		return fmt.Errorf("litestream service %s: unknown command", cmd)
+	}
+}
+
+// runDebug runs the service handler interactively in the current console,
+// logging to stderr instead of the Windows Event Log. This is useful for
+// iterating on service behavior without installing/uninstalling the service.
+func (c *ServiceCommand) runDebug(ctx context.Context, args []string) (err error) {
+	return runWindowsServiceMode(ctx, true)
+}
+
+// runInstall registers Litestream as an auto-start (delayed) Windows service
+// that runs the current executable with the given config file. It also
+// configures recovery actions so the service restarts itself on failure.
+func (c *ServiceCommand) runInstall(ctx context.Context, args []string) (err error) {
+	fs := flag.NewFlagSet("litestream-service-install", flag.ContinueOnError)
+	configPath := fs.String("config", "", "config path")
+	username := fs.String("username", "", "service logon account (e.g. .\\svc-litestream)")
+	password := fs.String("password", "", "service logon password")
+	if err := fs.Parse(args); err != nil {
+		return err
+	} else if *configPath == "" {
+		return fmt.Errorf("config path required")
+	}
+
+	exePath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot determine executable path: %w", err)
+	}
+
+	// Install the event log source before registering the service so that
+	// the service is never left without an event source to log to.
+	if err := eventlog.InstallAsEventCreate(serviceName, eventlog.Error|eventlog.Warning|eventlog.Info); err != nil {
+		return fmt.Errorf("cannot install event log source: %w", err)
+	}
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("cannot connect to service manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	if s, err := m.OpenService(serviceName); err == nil {
+		s.Close()
+		return fmt.Errorf("service %q already installed", serviceName)
+	}
+
+	cfg := mgr.Config{
+		DisplayName:      serviceName,
+		Description:      "Replicates SQLite databases for disaster recovery.",
+		StartType:        mgr.StartAutomatic,
+		DelayedAutoStart: true,
+		ServiceStartName: *username,
+		Password:         *password,
+	}
+
+	s, err := m.CreateService(serviceName, exePath, cfg, "replicate", "-config", *configPath)
+	if err != nil {
+		return fmt.Errorf("cannot create service: %w", err)
+	}
+	defer s.Close()
+
+	if err := s.SetRecoveryActions([]mgr.RecoveryAction{
+		{Type: mgr.ServiceRestart, Delay: 10 * time.Second},
+		{Type: mgr.ServiceRestart, Delay: 30 * time.Second},
+		{Type: mgr.ServiceRestart, Delay: 60 * time.Second},
+	}, uint32((24 * time.Hour).Seconds())); err != nil {
+		// Roll back the service registration so a failed install doesn't
+		// leave a half-configured service behind.
+		if delErr := s.Delete(); delErr != nil {
+			return fmt.Errorf("cannot set recovery actions: %w (and failed to roll back service: %s)", err, delErr)
+		}
+		return fmt.Errorf("cannot set recovery actions: %w", err)
+	}
+
+	fmt.Printf("service %q installed\n", serviceName)
+	return nil
+}
+
+// runUninstall removes the Litestream service registration and its event log source.
+func (c *ServiceCommand) runUninstall(ctx context.Context, args []string) (err error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("cannot connect to service manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(serviceName)
+	if err != nil {
+		return fmt.Errorf("service %q is not installed: %w", serviceName, err)
+	}
+	defer s.Close()
+
+	if err := s.Delete(); err != nil {
+		return fmt.Errorf("cannot delete service: %w", err)
+	}
+
+	if err := eventlog.Remove(serviceName); err != nil {
+		return fmt.Errorf("cannot remove event log source: %w", err)
+	}
+
+	fmt.Printf("service %q uninstalled\n", serviceName)
+	return nil
+}
+
+// runStart starts the Litestream service.
+func (c *ServiceCommand) runStart(ctx context.Context, args []string) (err error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("cannot connect to service manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(serviceName)
+	if err != nil {
+		return fmt.Errorf("service %q is not installed: %w", serviceName, err)
+	}
+	defer s.Close()
+
+	if err := s.Start(); err != nil {
+		return fmt.Errorf("cannot start service: %w", err)
+	}
+
+	fmt.Printf("service %q started\n", serviceName)
+	return nil
+}
+
+// runStop stops the Litestream service.
+func (c *ServiceCommand) runStop(ctx context.Context, args []string) (err error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("cannot connect to service manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(serviceName)
+	if err != nil {
+		return fmt.Errorf("service %q is not installed: %w", serviceName, err)
+	}
+	defer s.Close()
+
+	status, err := s.Control(svc.Stop)
+	if err != nil {
+		return fmt.Errorf("cannot stop service: %w", err)
+	}
+
+	fmt.Printf("service %q stopping, state=%d\n", serviceName, status.State)
+	return nil
+}
+
+// runStatus prints the current state of the Litestream service.
+func (c *ServiceCommand) runStatus(ctx context.Context, args []string) (err error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("cannot connect to service manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(serviceName)
+	if err != nil {
+		return fmt.Errorf("service %q is not installed: %w", serviceName, err)
+	}
+	defer s.Close()
+
+	status, err := s.Query()
+	if err != nil {
+		return fmt.Errorf("cannot query service: %w", err)
+	}
+
+	fmt.Printf("service %q: state=%d\n", serviceName, status.State)
+	return nil
+}
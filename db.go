@@ -0,0 +1,211 @@
+package litestream
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultMonitorInterval is the default frequency at which a DB is synced to
+// its replicas.
+const DefaultMonitorInterval = 1 * time.Second
+
+// DB represents a managed instance of a SQLite database in the file system.
+type DB struct {
+	mu    sync.Mutex
+	path  string
+	sqldb *sql.DB
+
+	// Replicas is the set of destinations this DB replicates its WAL to.
+	Replicas []*Replica
+
+	// MonitorInterval is the frequency at which the DB is synced to its
+	// replicas.
+	MonitorInterval time.Duration
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	paused bool
+}
+
+// NewDB returns a new instance of DB for the given path.
+func NewDB(path string) *DB {
+	return &DB{
+		path:            path,
+		MonitorInterval: DefaultMonitorInterval,
+	}
+}
+
+// Path returns the path to the underlying SQLite database.
+func (db *DB) Path() string { return db.path }
+
+// Open opens a handle to the database, connects its replicas, and begins
+// monitoring it for changes. sql.Open does not connect, so the database file
+// itself is not checked here.
+func (db *DB) Open() (err error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.sqldb, err = sql.Open("sqlite3", db.path); err != nil {
+		return fmt.Errorf("open db: %w", err)
+	}
+
+	for _, r := range db.Replicas {
+		if err := r.Open(); err != nil {
+			return fmt.Errorf("open replica %q: %w", r.Name, err)
+		}
+	}
+
+	db.startMonitor()
+
+	return nil
+}
+
+// Close stops the monitor loop, disconnects all replicas, and closes the
+// underlying SQLite connection.
+func (db *DB) Close() (err error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.stopMonitor()
+
+	for _, r := range db.Replicas {
+		if e := r.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	if db.sqldb != nil {
+		if e := db.sqldb.Close(); e != nil && err == nil {
+			err = e
+		}
+		db.sqldb = nil
+	}
+
+	return err
+}
+
+// Pause stops the background monitor loop and disconnects replicas, but
+// leaves the underlying SQLite file handle and shadow WAL state intact so
+// Resume can continue replicating without a full re-open, snapshot check, or
+// reconnect of every replica. Pause is idempotent.
+func (db *DB) Pause() (err error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.paused {
+		return nil
+	}
+
+	db.stopMonitor()
+
+	for _, r := range db.Replicas {
+		if e := r.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	db.paused = true
+	return err
+}
+
+// Resume reconnects replicas and restarts the background monitor loop after
+// a prior call to Pause. Resume is idempotent.
+func (db *DB) Resume() (err error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if !db.paused {
+		return nil
+	}
+
+	for _, r := range db.Replicas {
+		if e := r.Open(); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	db.startMonitor()
+	db.paused = false
+	return err
+}
+
+// Checkpoint forces a WAL checkpoint and syncs the result to all replicas. It
+// can be called whether or not the DB is currently paused: a paused
+// replica's connection is reopened (Resume's subsequent Open is a no-op) so
+// the upload actually happens rather than silently no-opping, which lets the
+// Windows service flush state ahead of a session lock/logoff or suspend
+// without resuming the monitor loop.
+func (db *DB) Checkpoint(ctx context.Context) (err error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.sqldb == nil {
+		return fmt.Errorf("db not open")
+	}
+
+	if _, err := db.sqldb.ExecContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE);`); err != nil {
+		return fmt.Errorf("checkpoint: %w", err)
+	}
+
+	for _, r := range db.Replicas {
+		if e := r.Open(); e != nil && err == nil {
+			err = e
+		}
+		if e := r.Sync(ctx); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// startMonitor begins the background goroutine that periodically syncs the
+// database to its replicas. The caller must hold db.mu.
+func (db *DB) startMonitor() {
+	ctx, cancel := context.WithCancel(context.Background())
+	db.cancel = cancel
+
+	db.wg.Add(1)
+	go func() {
+		defer db.wg.Done()
+		db.monitor(ctx)
+	}()
+}
+
+// stopMonitor cancels the background monitor goroutine, if running, and
+// waits for it to exit. The caller must hold db.mu; it is temporarily
+// released while waiting so a sync in flight can finish.
+func (db *DB) stopMonitor() {
+	if db.cancel == nil {
+		return
+	}
+
+	cancel := db.cancel
+	db.cancel = nil
+
+	cancel()
+	db.mu.Unlock()
+	db.wg.Wait()
+	db.mu.Lock()
+}
+
+// monitor periodically syncs the database to its replicas until ctx is
+// canceled.
+func (db *DB) monitor(ctx context.Context) {
+	ticker := time.NewTicker(db.MonitorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, r := range db.Replicas {
+				_ = r.Sync(ctx)
+			}
+		}
+	}
+}
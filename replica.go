@@ -0,0 +1,88 @@
+package litestream
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Client is the minimal interface a replica destination must implement to be
+// managed by a Replica.
+type Client interface {
+	// Open establishes (or re-establishes) a connection to the destination.
+	Open() error
+
+	// Close releases any connection held by the client.
+	Close() error
+
+	// Sync uploads any outstanding WAL data to the destination.
+	Sync(ctx context.Context) error
+
+	// Verify confirms the destination is reachable and consistent.
+	Verify(ctx context.Context) error
+}
+
+// Replica represents a destination that a DB replicates its WAL to.
+type Replica struct {
+	mu     sync.Mutex
+	Name   string
+	Client Client
+	opened bool
+}
+
+// Open connects the replica's underlying client. Open is idempotent. The
+// zero-value Replica is unopened, so the first call always connects.
+func (r *Replica) Open() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.opened {
+		return nil
+	}
+	if err := r.Client.Open(); err != nil {
+		return fmt.Errorf("open replica %q: %w", r.Name, err)
+	}
+	r.opened = true
+	return nil
+}
+
+// Close disconnects the replica's underlying client. Close is idempotent.
+func (r *Replica) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.opened {
+		return nil
+	}
+	if err := r.Client.Close(); err != nil {
+		return fmt.Errorf("close replica %q: %w", r.Name, err)
+	}
+	r.opened = false
+	return nil
+}
+
+// Sync uploads any outstanding WAL data to the replica. Sync is a no-op on an
+// unopened (paused) replica rather than an error, since a paused DB's
+// replicas are expected to sit idle until Resume reconnects them.
+func (r *Replica) Sync(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.opened {
+		return nil
+	}
+	return r.Client.Sync(ctx)
+}
+
+// Verify reconnects the replica if needed and confirms it is reachable. This
+// is used after a system resume to catch a destination that dropped its
+// connection while the machine was asleep.
+func (r *Replica) Verify(ctx context.Context) error {
+	if err := r.Open(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.Client.Verify(ctx)
+}